@@ -0,0 +1,70 @@
+package chord
+
+import (
+	"sync"
+	"time"
+)
+
+// Vnode represents a single virtual node in the ring, identified by
+// its hashed Id and reachable at Host. Incarnation distinguishes a
+// freshly-started Vnode from a ghost entry left behind by a previous
+// instance at the same Id; see incarnation.go.
+type Vnode struct {
+	Id          []byte
+	Host        string
+	Incarnation uint64
+}
+
+// localVnode is a Vnode we host locally, along with all the state
+// needed to run the stabilization protocol against it.
+type localVnode struct {
+	Vnode
+	ring         *Ring
+	successors   []*Vnode
+	finger       []*Vnode
+	last_finger  int
+	predecessor  *Vnode
+	stabilized   time.Time
+	timer        *time.Timer
+	succFailures int
+	topics       *topicTable
+	tasks        *taskSchedule
+	skew         *clockSkewState
+}
+
+// VnodeRPC is the set of calls a Transport dispatches to whichever
+// localVnode is addressed by Register.
+type VnodeRPC interface {
+	GetPredecessor() (*Vnode, error)
+	Notify(self *Vnode) ([]*Vnode, error)
+	FindSuccessors(n int, key []byte) ([]*Vnode, error)
+	RequestTicket(topic string) (*Ticket, error)
+	RegisterAdvert(topic string, ticket *Ticket, adv *Vnode, ttl time.Duration, meta []byte) error
+	QueryTopic(topic string, maxResults int) ([]*Advert, error)
+	SampleClock(from *Vnode, sendTS time.Time) error
+}
+
+// Transport is how a Ring talks to Vnodes, whether local or remote.
+type Transport interface {
+	GetPredecessor(vn *Vnode) (*Vnode, error)
+	Notify(vn, self *Vnode) ([]*Vnode, error)
+	Ping(vn *Vnode) (bool, error)
+	FindSuccessors(vn *Vnode, n int, key []byte) ([]*Vnode, error)
+	ListVnodes(host string) ([]*Vnode, error)
+	Register(vn *Vnode, o VnodeRPC)
+
+	RequestTicket(vn *Vnode, topic string) (*Ticket, error)
+	RegisterAdvert(vn *Vnode, topic string, ticket *Ticket, adv *Vnode, ttl time.Duration, meta []byte) error
+	QueryTopic(vn *Vnode, topic string, maxResults int) ([]*Advert, error)
+}
+
+// Ring is a Chord ring as seen from the local vnodes participating in
+// it.
+type Ring struct {
+	config    *Config
+	transport Transport
+	vnodes    []*localVnode
+	shutdown  bool
+	eventsMu  sync.Mutex
+	events    chan interface{}
+}
@@ -0,0 +1,228 @@
+package chord
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// clockSkewSampleInterval is how often a vnode piggybacks a timestamp
+// sample onto its successor during stabilize.
+const clockSkewSampleInterval = 10 * time.Minute
+
+// defaultClockSkewThreshold is the median offset past which a vnode
+// reports a ClockSkewEvent, used when Config.ClockSkewThreshold is zero.
+const defaultClockSkewThreshold = 10 * time.Second
+
+// skewSampleCap bounds how many distinct-peer samples are kept; once
+// full, the oldest sample is evicted to make room for a new peer.
+const skewSampleCap = 32
+
+// skewMinSamples is the minimum number of distinct-peer samples before
+// a median offset is computed at all.
+const skewMinSamples = 8
+
+// skewMedianWindow is how many of the middle, sorted samples are
+// averaged to produce the offset estimate, discarding the extremes so
+// a handful of lying or laggy peers can't skew the result.
+const skewMedianWindow = 8
+
+// ClockSamplerTransport is an optional Transport extension for
+// exchanging NTP-style timestamp samples. A Transport that doesn't
+// implement it simply never participates in skew detection; this
+// keeps the core Transport interface, and existing implementations of
+// it, unchanged.
+type ClockSamplerTransport interface {
+	// SampleClock asks target to record a timestamp sample from us.
+	SampleClock(target *Vnode, from *Vnode, sendTS time.Time) error
+}
+
+// ClockSkewEvent is emitted on Ring.Events() when a vnode's estimated
+// offset from its peers exceeds the configured threshold.
+type ClockSkewEvent struct {
+	Vnode    *Vnode
+	Offset   time.Duration
+	Samples  int
+	Detected time.Time
+}
+
+type skewSample struct {
+	peer   string
+	offset time.Duration
+}
+
+// clockSkewState is the per-vnode NTP-style sampling buffer.
+type clockSkewState struct {
+	sync.Mutex
+	samples  []skewSample
+	lastSent time.Time
+	poisoned bool // true once a skew event fires, until acknowledged
+}
+
+func newClockSkewState() *clockSkewState {
+	return &clockSkewState{}
+}
+
+// record upserts the offset sample for peer, evicting the oldest entry
+// if the buffer is already at capacity.
+func (s *clockSkewState) record(peer string, offset time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	for i, sample := range s.samples {
+		if sample.peer == peer {
+			s.samples[i].offset = offset
+			return
+		}
+	}
+	if len(s.samples) >= skewSampleCap {
+		s.samples = s.samples[1:]
+	}
+	s.samples = append(s.samples, skewSample{peer: peer, offset: offset})
+}
+
+// median computes the offset estimate from the current samples: sort
+// them, then average the middle skewMedianWindow entries. Returns
+// false if there aren't yet skewMinSamples distinct-peer samples.
+func (s *clockSkewState) median() (time.Duration, int, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	n := len(s.samples)
+	if n < skewMinSamples {
+		return 0, n, false
+	}
+
+	offsets := make([]time.Duration, n)
+	for i, sample := range s.samples {
+		offsets[i] = sample.offset
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	window := skewMedianWindow
+	if window > n {
+		window = n
+	}
+	start := (n - window) / 2
+
+	var sum time.Duration
+	for _, off := range offsets[start : start+window] {
+		sum += off
+	}
+	return sum / time.Duration(window), n, true
+}
+
+// clockSkewThreshold returns the configured threshold, or the default
+// if Config.ClockSkewThreshold is unset.
+func clockSkewThreshold(conf *Config) time.Duration {
+	if conf.ClockSkewThreshold > 0 {
+		return conf.ClockSkewThreshold
+	}
+	return defaultClockSkewThreshold
+}
+
+// emitEvent delivers evt on the ring's event channel without blocking
+// if nobody is listening, and is a no-op if Events() was never called.
+// SampleClock can run concurrently across every local vnode's inbound
+// RPCs on the same Ring, so r.events is created lazily under eventsMu
+// rather than read/written bare.
+func emitEvent(r *Ring, evt interface{}) {
+	r.eventsMu.Lock()
+	ch := r.events
+	r.eventsMu.Unlock()
+
+	if ch == nil {
+		return
+	}
+	select {
+	case ch <- evt:
+	default:
+	}
+}
+
+// Events returns a channel on which the ring delivers operational
+// events, currently just ClockSkewEvent. The channel is created lazily
+// and is buffered, so slow consumers drop events rather than stalling
+// the ring.
+func (r *Ring) Events() <-chan interface{} {
+	r.eventsMu.Lock()
+	defer r.eventsMu.Unlock()
+
+	if r.events == nil {
+		r.events = make(chan interface{}, 16)
+	}
+	return r.events
+}
+
+// SampleClock: RPC invoked by a peer to record a clock-offset sample
+// against us. Once enough distinct peers have reported in, we compute
+// the median offset and, if it exceeds the configured threshold,
+// refuse to participate in fixFingerTable until acknowledged.
+func (vn *localVnode) SampleClock(from *Vnode, sendTS time.Time) error {
+	offset := time.Since(sendTS)
+	vn.skew.record(from.String(), offset)
+
+	median, n, ok := vn.skew.median()
+	if !ok {
+		return nil
+	}
+
+	abs := median
+	if abs < 0 {
+		abs = -abs
+	}
+	if abs > clockSkewThreshold(vn.ring.config) {
+		vn.skew.Lock()
+		already := vn.skew.poisoned
+		vn.skew.poisoned = true
+		vn.skew.Unlock()
+
+		if !already {
+			log.Printf("[WARN] Vnode %s clock offset %s exceeds threshold over %d samples", vn.String(), median, n)
+			emitEvent(vn.ring, ClockSkewEvent{
+				Vnode:    &vn.Vnode,
+				Offset:   median,
+				Samples:  n,
+				Detected: time.Now(),
+			})
+		}
+	}
+	return nil
+}
+
+// AcknowledgeClockSkew clears a vnode's poisoned state, re-enabling
+// fixFingerTable. Operators call this once they've confirmed (and
+// corrected) the host's clock.
+func (vn *localVnode) AcknowledgeClockSkew() {
+	vn.skew.Lock()
+	vn.skew.poisoned = false
+	vn.skew.Unlock()
+}
+
+// maybeSampleClock piggybacks a timestamp sample on the successor
+// relationship at most once per clockSkewSampleInterval, if the
+// transport supports it.
+func (vn *localVnode) maybeSampleClock(succ *Vnode) {
+	sampler, ok := vn.ring.transport.(ClockSamplerTransport)
+	if !ok || succ == nil {
+		return
+	}
+
+	// This runs concurrently with SampleClock handling inbound RPCs,
+	// so lastSent needs the same lock every other accessor in this
+	// file uses rather than being read/written bare.
+	vn.skew.Lock()
+	due := time.Since(vn.skew.lastSent) >= clockSkewSampleInterval
+	if due {
+		vn.skew.lastSent = time.Now()
+	}
+	vn.skew.Unlock()
+	if !due {
+		return
+	}
+
+	if err := sampler.SampleClock(succ, &vn.Vnode, time.Now()); err != nil {
+		log.Printf("[ERR] Failed to sample clock against %s: %s", succ.String(), err)
+	}
+}
@@ -0,0 +1,115 @@
+package chord
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClockSkewStateMedianRequiresMinSamples(t *testing.T) {
+	s := newClockSkewState()
+	for i := 0; i < skewMinSamples-1; i++ {
+		s.record(string(rune('a'+i)), time.Duration(i)*time.Second)
+	}
+	if _, _, ok := s.median(); ok {
+		t.Fatalf("expected median to refuse to estimate below skewMinSamples")
+	}
+
+	s.record("last", time.Second)
+	if _, n, ok := s.median(); !ok || n != skewMinSamples {
+		t.Fatalf("expected median to estimate once skewMinSamples is reached, got n=%d ok=%v", n, ok)
+	}
+}
+
+func TestClockSkewStateMedianDiscardsExtremes(t *testing.T) {
+	s := newClockSkewState()
+	// Two wild outliers plus skewMinSamples consistent mid-range samples;
+	// the windowed median should reflect the cluster, not the outliers.
+	s.record("low-outlier", -time.Hour)
+	s.record("high-outlier", time.Hour)
+	for i := 0; i < skewMinSamples; i++ {
+		s.record(string(rune('a'+i)), 100*time.Millisecond)
+	}
+
+	median, n, ok := s.median()
+	if !ok {
+		t.Fatalf("expected enough samples for a median")
+	}
+	if n != skewMinSamples+2 {
+		t.Fatalf("expected median to report the full sample count, got %d", n)
+	}
+	if median != 100*time.Millisecond {
+		t.Fatalf("expected the outliers to be discarded from the windowed median, got %s", median)
+	}
+}
+
+func TestClockSkewStateRecordUpsertsPerPeer(t *testing.T) {
+	s := newClockSkewState()
+	s.record("peer", time.Second)
+	s.record("peer", 2*time.Second)
+	if len(s.samples) != 1 || s.samples[0].offset != 2*time.Second {
+		t.Fatalf("expected a repeated peer to update its sample in place, got %+v", s.samples)
+	}
+}
+
+func TestClockSkewStateRecordEvictsOldestAtCapacity(t *testing.T) {
+	s := newClockSkewState()
+	for i := 0; i < skewSampleCap; i++ {
+		s.record(string(rune('a'+i)), time.Duration(i)*time.Millisecond)
+	}
+	s.record("newcomer", time.Hour)
+
+	if len(s.samples) != skewSampleCap {
+		t.Fatalf("expected the sample buffer to stay capped at %d, got %d", skewSampleCap, len(s.samples))
+	}
+	if s.samples[0].peer != string(rune('a'+1)) {
+		t.Fatalf("expected the oldest sample to be evicted to make room, got %+v", s.samples[0])
+	}
+}
+
+func TestClockSkewThresholdDefaultsWhenUnset(t *testing.T) {
+	if got := clockSkewThreshold(&Config{}); got != defaultClockSkewThreshold {
+		t.Fatalf("expected the default threshold when Config.ClockSkewThreshold is unset, got %s", got)
+	}
+	if got := clockSkewThreshold(&Config{ClockSkewThreshold: time.Minute}); got != time.Minute {
+		t.Fatalf("expected a configured threshold to override the default, got %s", got)
+	}
+}
+
+func TestEmitEventNoopWithoutEventsChannel(t *testing.T) {
+	r := &Ring{}
+	emitEvent(r, ClockSkewEvent{}) // must not panic or block
+}
+
+func TestEmitEventDropsWhenChannelFull(t *testing.T) {
+	r := &Ring{}
+	ch := r.Events()
+	for i := 0; i < cap(ch); i++ {
+		emitEvent(r, i)
+	}
+	emitEvent(r, "overflow") // must not block once the buffer is full
+
+	if len(ch) != cap(ch) {
+		t.Fatalf("expected the channel to stay at capacity, got %d/%d", len(ch), cap(ch))
+	}
+}
+
+func TestEventsConcurrentWithEmitEventIsRaceFree(t *testing.T) {
+	r := &Ring{}
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			r.Events()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			emitEvent(r, i)
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,49 @@
+package chord
+
+import (
+	"crypto/sha1"
+	"hash"
+	"time"
+)
+
+// Config governs a Ring's identity, topology, and the optional
+// subsystems layered on top of core Chord routing (peer persistence,
+// clock-skew detection).
+type Config struct {
+	Hostname      string // Local host used to identify this ring member
+	NumVnodes     int    // Number of local vnodes to host
+	NumSuccessors int    // Number of successors to maintain per vnode
+	HashFunc      func() hash.Hash
+	HashBits      int           // Bit length of the hash function's output
+	StabilizeMin  time.Duration // Minimum stabilization interval
+	StabilizeMax  time.Duration // Maximum stabilization interval
+
+	// StateDir is where per-vnode state that must survive a restart
+	// (currently just incarnation counters) is persisted. Defaults to
+	// the current directory when empty.
+	StateDir string
+
+	// PeerStore, if set, is consulted by Join to find bootstrap seeds
+	// and kept up to date as vnodes learn about live remote peers.
+	// Optional: a nil PeerStore disables persistence entirely.
+	PeerStore PeerStore
+
+	// ClockSkewThreshold is the median peer clock offset past which a
+	// vnode reports a ClockSkewEvent and refuses finger repair until
+	// acknowledged. Defaults to defaultClockSkewThreshold when zero.
+	ClockSkewThreshold time.Duration
+}
+
+// DefaultConfig returns a Config with the same defaults this ring has
+// always shipped with, for the given hostname.
+func DefaultConfig(hostname string) *Config {
+	return &Config{
+		Hostname:      hostname,
+		NumVnodes:     8,
+		NumSuccessors: 8,
+		HashFunc:      sha1.New,
+		HashBits:      160,
+		StabilizeMin:  15 * time.Millisecond,
+		StabilizeMax:  8 * time.Second,
+	}
+}
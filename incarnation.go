@@ -0,0 +1,92 @@
+package chord
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// incarnationFile returns the path used to persist idx's incarnation
+// counter across restarts. Config.StateDir defaults to the current
+// directory when unset.
+func incarnationFile(conf *Config, idx uint16) string {
+	dir := conf.StateDir
+	if dir == "" {
+		dir = "."
+	}
+	name := fmt.Sprintf(".chord-incarnation-%s-%d", conf.Hostname, idx)
+	return filepath.Join(dir, name)
+}
+
+// nextIncarnation loads the last persisted incarnation number for idx,
+// bumps it, writes the new value back, and returns it. A host that
+// crashes and rejoins therefore always announces a higher incarnation
+// than its ghost entries still held by neighbours; a missing or
+// corrupt file starts the counter at 1.
+func nextIncarnation(conf *Config, idx uint16) uint64 {
+	path := incarnationFile(conf, idx)
+
+	var inc uint64
+	if data, err := os.ReadFile(path); err == nil {
+		if n, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err == nil {
+			inc = n
+		}
+	}
+	inc++
+
+	if err := writeIncarnationFile(path, inc); err != nil {
+		log.Printf("[ERR] Failed to persist incarnation for %s/%d: %s", conf.Hostname, idx, err)
+	}
+	return inc
+}
+
+// writeIncarnationFile persists inc to path via write-tmp-then-rename,
+// the same pattern FilePeerStore.save uses, so a crash mid-write can
+// never leave a truncated/corrupt counter file behind -- which would
+// otherwise read back as "no prior incarnation" and restart the
+// counter at 1, defeating the one guarantee this file exists to give.
+func writeIncarnationFile(path string, inc uint64) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(inc, 10)), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// supersedes reports whether a should replace b in successor lists and
+// finger tables: same logical Id, strictly higher incarnation. Vnodes
+// with different Ids are never comparable this way.
+func supersedes(a, b *Vnode) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if !bytes.Equal(a.Id, b.Id) {
+		return false
+	}
+	return a.Incarnation > b.Incarnation
+}
+
+// reconcileIncarnation returns whichever of existing/candidate should
+// be kept for the same logical Id, favoring the higher incarnation.
+// This is the anti-entropy check run inline during notifySuccessor so
+// an incarnation bump propagates as soon as two vnodes next talk,
+// rather than waiting for a full finger repair cycle.
+func reconcileIncarnation(existing, candidate *Vnode) *Vnode {
+	if existing == nil {
+		return candidate
+	}
+	if candidate == nil {
+		return existing
+	}
+	if !bytes.Equal(existing.Id, candidate.Id) {
+		return candidate
+	}
+	if candidate.Incarnation >= existing.Incarnation {
+		return candidate
+	}
+	return existing
+}
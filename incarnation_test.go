@@ -0,0 +1,107 @@
+package chord
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSupersedes(t *testing.T) {
+	a := &Vnode{Id: []byte{1}, Incarnation: 2}
+	b := &Vnode{Id: []byte{1}, Incarnation: 1}
+	if !supersedes(a, b) {
+		t.Fatalf("expected higher incarnation of the same Id to supersede")
+	}
+	if supersedes(b, a) {
+		t.Fatalf("expected lower incarnation to not supersede")
+	}
+}
+
+func TestSupersedesDifferentIdsOrNil(t *testing.T) {
+	a := &Vnode{Id: []byte{1}, Incarnation: 2}
+	c := &Vnode{Id: []byte{2}, Incarnation: 1}
+	if supersedes(a, c) {
+		t.Fatalf("expected vnodes with different Ids to never supersede")
+	}
+	if supersedes(nil, a) || supersedes(a, nil) {
+		t.Fatalf("expected a nil operand to never supersede or be superseded")
+	}
+}
+
+func TestReconcileIncarnation(t *testing.T) {
+	existing := &Vnode{Id: []byte{1}, Incarnation: 1}
+	candidate := &Vnode{Id: []byte{1}, Incarnation: 2}
+	if got := reconcileIncarnation(existing, candidate); got != candidate {
+		t.Fatalf("expected the higher incarnation to win")
+	}
+	if got := reconcileIncarnation(candidate, existing); got != candidate {
+		t.Fatalf("expected the higher incarnation to win regardless of argument order")
+	}
+}
+
+func TestReconcileIncarnationNilAndDifferentIds(t *testing.T) {
+	candidate := &Vnode{Id: []byte{1}, Incarnation: 1}
+	if got := reconcileIncarnation(nil, candidate); got != candidate {
+		t.Fatalf("expected candidate to be returned when existing is nil")
+	}
+	existing := &Vnode{Id: []byte{1}, Incarnation: 5}
+	if got := reconcileIncarnation(existing, nil); got != existing {
+		t.Fatalf("expected existing to be returned when candidate is nil")
+	}
+
+	other := &Vnode{Id: []byte{2}, Incarnation: 1}
+	if got := reconcileIncarnation(existing, other); got != other {
+		t.Fatalf("expected a different Id candidate to replace existing outright")
+	}
+}
+
+func TestNextIncarnationPersistsAndIncrements(t *testing.T) {
+	dir := t.TempDir()
+	conf := &Config{Hostname: "h1", StateDir: dir}
+
+	first := nextIncarnation(conf, 0)
+	second := nextIncarnation(conf, 0)
+	if second != first+1 {
+		t.Fatalf("expected nextIncarnation to increment across calls, got %d then %d", first, second)
+	}
+
+	path := incarnationFile(conf, 0)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected incarnation file to exist at %s: %v", path, err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("expected the .tmp file to be renamed away, not left behind")
+	}
+}
+
+func TestNextIncarnationSurvivesCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	conf := &Config{Hostname: "h1", StateDir: dir}
+	path := incarnationFile(conf, 0)
+
+	if err := os.WriteFile(path, []byte("not-a-number"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if got := nextIncarnation(conf, 0); got != 1 {
+		t.Fatalf("expected a corrupt file to be treated as no prior incarnation, got %d", got)
+	}
+}
+
+func TestWriteIncarnationFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "inc")
+
+	if err := writeIncarnationFile(path, 7); err != nil {
+		t.Fatalf("writeIncarnationFile: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != "7" {
+		t.Fatalf("expected persisted value %q, got %q", "7", data)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("expected no leftover .tmp file after a successful write")
+	}
+}
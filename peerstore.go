@@ -0,0 +1,238 @@
+package chord
+
+import (
+	"encoding/gob"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// peerTTL is how long a peer record is retained without being
+// refreshed before it is evicted from a PeerStore.
+const peerTTL = 24 * time.Hour
+
+// minRepingInterval is the minimum time between two Record calls for
+// the same peer, so a busy vnode doesn't hammer its own store.
+const minRepingInterval = time.Second
+
+// PeerRecord is what a PeerStore persists about a remote vnode we have
+// successfully contacted.
+type PeerRecord struct {
+	Vnode    *Vnode
+	Host     string
+	LastSeen time.Time
+	LastPong time.Time
+	Dead     bool
+}
+
+// PeerStore lets a Ring remember vnodes it has talked to across
+// restarts, so Join can reconnect to the ring without a bootstrap host.
+// Implementations must be safe for concurrent use.
+type PeerStore interface {
+	// Record upserts a record for a live peer, refreshing LastSeen.
+	Record(rec *PeerRecord) error
+
+	// MarkDead flags a peer as unresponsive without deleting it,
+	// so a flapping peer decays out gracefully rather than being
+	// forgotten after a single failed ping.
+	MarkDead(vn *Vnode) error
+
+	// Seeds returns up to n recently-seen, non-dead peers in
+	// most-recently-seen order, for use as Join bootstrap targets.
+	Seeds(n int) []*PeerRecord
+}
+
+// recordPeer upserts a peer into store. It does no throttling itself;
+// MemPeerStore and FilePeerStore ignore a repeat Record within
+// minRepingInterval, so repeated stabilize ticks against the same peer
+// don't thrash them. store may be nil, in which case this is a no-op
+// (PeerStore is optional).
+func recordPeer(store PeerStore, vn *Vnode, host string) {
+	if store == nil || vn == nil {
+		return
+	}
+	store.Record(&PeerRecord{
+		Vnode:    vn,
+		Host:     host,
+		LastSeen: time.Now(),
+	})
+}
+
+// MemPeerStore is a non-persistent PeerStore, used as the default in
+// tests and anywhere disk persistence isn't wanted.
+type MemPeerStore struct {
+	sync.Mutex
+	peers map[string]*PeerRecord
+}
+
+// NewMemPeerStore creates an empty in-memory PeerStore.
+func NewMemPeerStore() *MemPeerStore {
+	return &MemPeerStore{peers: make(map[string]*PeerRecord)}
+}
+
+func (m *MemPeerStore) Record(rec *PeerRecord) error {
+	m.Lock()
+	defer m.Unlock()
+
+	key := rec.Vnode.String()
+	if existing, ok := m.peers[key]; ok {
+		if time.Since(existing.LastSeen) < minRepingInterval {
+			return nil
+		}
+		existing.LastSeen = rec.LastSeen
+		existing.Host = rec.Host
+		existing.Dead = false
+		return nil
+	}
+	cp := *rec
+	m.peers[key] = &cp
+	return nil
+}
+
+func (m *MemPeerStore) MarkDead(vn *Vnode) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if rec, ok := m.peers[vn.String()]; ok {
+		rec.Dead = true
+		rec.LastPong = time.Now()
+	}
+	return nil
+}
+
+func (m *MemPeerStore) Seeds(n int) []*PeerRecord {
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now()
+	var live []*PeerRecord
+	for key, rec := range m.peers {
+		if rec.Dead || now.Sub(rec.LastSeen) > peerTTL {
+			delete(m.peers, key)
+			continue
+		}
+		live = append(live, rec)
+	}
+	sort.Slice(live, func(i, j int) bool {
+		return live[i].LastSeen.After(live[j].LastSeen)
+	})
+	if len(live) > n {
+		live = live[:n]
+	}
+	return live
+}
+
+// FilePeerStore is a disk-backed PeerStore that gob-encodes the peer
+// table on every write. It is a much simpler stand-in for the
+// leveldb-backed node database used by production deployments, without
+// pulling in an external storage dependency.
+type FilePeerStore struct {
+	sync.Mutex
+	path  string
+	peers map[string]*PeerRecord
+}
+
+// NewFilePeerStore opens (or creates) a peer store persisted at path.
+func NewFilePeerStore(path string) (*FilePeerStore, error) {
+	fps := &FilePeerStore{path: path, peers: make(map[string]*PeerRecord)}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fps, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := gob.NewDecoder(f).Decode(&fps.peers); err != nil {
+		return nil, err
+	}
+	return fps, nil
+}
+
+func (f *FilePeerStore) save() error {
+	tmp := f.path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(out).Encode(f.peers); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
+
+func (f *FilePeerStore) Record(rec *PeerRecord) error {
+	f.Lock()
+	defer f.Unlock()
+
+	key := rec.Vnode.String()
+	if existing, ok := f.peers[key]; ok {
+		if time.Since(existing.LastSeen) < minRepingInterval {
+			return nil
+		}
+		existing.LastSeen = rec.LastSeen
+		existing.Host = rec.Host
+		existing.Dead = false
+	} else {
+		cp := *rec
+		f.peers[key] = &cp
+	}
+	return f.save()
+}
+
+func (f *FilePeerStore) MarkDead(vn *Vnode) error {
+	f.Lock()
+	defer f.Unlock()
+
+	rec, ok := f.peers[vn.String()]
+	if !ok {
+		return nil
+	}
+	rec.Dead = true
+	rec.LastPong = time.Now()
+	return f.save()
+}
+
+func (f *FilePeerStore) Seeds(n int) []*PeerRecord {
+	f.Lock()
+	defer f.Unlock()
+
+	now := time.Now()
+	var live []*PeerRecord
+	dirty := false
+	for key, rec := range f.peers {
+		if rec.Dead || now.Sub(rec.LastSeen) > peerTTL {
+			delete(f.peers, key)
+			dirty = true
+			continue
+		}
+		live = append(live, rec)
+	}
+	if dirty {
+		f.save()
+	}
+	sort.Slice(live, func(i, j int) bool {
+		return live[i].LastSeen.After(live[j].LastSeen)
+	})
+	if len(live) > n {
+		live = live[:n]
+	}
+	return live
+}
+
+// seedsFromPeerStore asks config's PeerStore (if any) for candidate
+// bootstrap peers. Ring.Join calls this before falling back to the
+// user-supplied bootstrap host, so a node that bounces can rejoin the
+// ring without depending on any single well-known address.
+func seedsFromPeerStore(conf *Config, n int) []*PeerRecord {
+	if conf.PeerStore == nil {
+		return nil
+	}
+	return conf.PeerStore.Seeds(n)
+}
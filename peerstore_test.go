@@ -0,0 +1,92 @@
+package chord
+
+import (
+	"testing"
+	"time"
+)
+
+func testVnode(id byte) *Vnode {
+	return &Vnode{Id: []byte{id}}
+}
+
+func TestMemPeerStoreRecordAndSeeds(t *testing.T) {
+	m := NewMemPeerStore()
+	vn := testVnode(1)
+	if err := m.Record(&PeerRecord{Vnode: vn, Host: "h1", LastSeen: time.Now()}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	seeds := m.Seeds(5)
+	if len(seeds) != 1 || seeds[0].Host != "h1" {
+		t.Fatalf("expected one seed for h1, got %+v", seeds)
+	}
+}
+
+func TestMemPeerStoreSeedsMostRecentFirst(t *testing.T) {
+	m := NewMemPeerStore()
+	now := time.Now()
+	m.peers["old"] = &PeerRecord{Vnode: testVnode(1), Host: "old", LastSeen: now.Add(-time.Minute)}
+	m.peers["new"] = &PeerRecord{Vnode: testVnode(2), Host: "new", LastSeen: now}
+
+	seeds := m.Seeds(2)
+	if len(seeds) != 2 || seeds[0].Host != "new" || seeds[1].Host != "old" {
+		t.Fatalf("expected most-recently-seen first, got %+v", seeds)
+	}
+}
+
+func TestMemPeerStoreSeedsRespectsLimit(t *testing.T) {
+	m := NewMemPeerStore()
+	for i := byte(0); i < 5; i++ {
+		m.peers[string(rune('a'+i))] = &PeerRecord{Vnode: testVnode(i), Host: string(rune('a' + i)), LastSeen: time.Now()}
+	}
+	if seeds := m.Seeds(2); len(seeds) != 2 {
+		t.Fatalf("expected Seeds to respect the requested limit, got %d", len(seeds))
+	}
+}
+
+func TestMemPeerStoreEvictsExpiredAndDead(t *testing.T) {
+	m := NewMemPeerStore()
+	m.peers["stale"] = &PeerRecord{Vnode: testVnode(1), Host: "stale", LastSeen: time.Now().Add(-(peerTTL + time.Minute))}
+	m.peers["dead"] = &PeerRecord{Vnode: testVnode(2), Host: "dead", LastSeen: time.Now(), Dead: true}
+	m.peers["live"] = &PeerRecord{Vnode: testVnode(3), Host: "live", LastSeen: time.Now()}
+
+	seeds := m.Seeds(10)
+	if len(seeds) != 1 || seeds[0].Host != "live" {
+		t.Fatalf("expected only the live peer to survive, got %+v", seeds)
+	}
+	if _, ok := m.peers["stale"]; ok {
+		t.Fatalf("expected the stale (TTL-expired) peer to be evicted from the store")
+	}
+	if _, ok := m.peers["dead"]; ok {
+		t.Fatalf("expected the dead peer to be evicted from the store")
+	}
+}
+
+func TestMemPeerStoreMarkDead(t *testing.T) {
+	m := NewMemPeerStore()
+	vn := testVnode(1)
+	m.Record(&PeerRecord{Vnode: vn, Host: "h1", LastSeen: time.Now()})
+
+	if err := m.MarkDead(vn); err != nil {
+		t.Fatalf("MarkDead: %v", err)
+	}
+	if seeds := m.Seeds(5); len(seeds) != 0 {
+		t.Fatalf("expected no seeds once the only peer is marked dead, got %+v", seeds)
+	}
+}
+
+func TestMemPeerStoreThrottlesRepeatedRecord(t *testing.T) {
+	m := NewMemPeerStore()
+	vn := testVnode(1)
+	m.Record(&PeerRecord{Vnode: vn, Host: "h1", LastSeen: time.Now()})
+	m.Record(&PeerRecord{Vnode: vn, Host: "h2", LastSeen: time.Now()})
+
+	seeds := m.Seeds(5)
+	if len(seeds) != 1 || seeds[0].Host != "h1" {
+		t.Fatalf("expected the second Record within minRepingInterval to be throttled, got %+v", seeds)
+	}
+}
+
+func TestRecordPeerNilStoreIsNoop(t *testing.T) {
+	recordPeer(nil, testVnode(1), "h1")
+}
@@ -0,0 +1,129 @@
+package chord
+
+import (
+	"fmt"
+	"sync"
+)
+
+// joinSeedFanout caps how many peer-store seeds Join contacts in
+// parallel before falling back to the caller-supplied bootstrap host.
+const joinSeedFanout = 3
+
+// init creates conf.NumVnodes local vnodes and registers them with
+// trans. Their successor lists start empty; setLocalSuccessors chains
+// them to each other afterwards.
+func (r *Ring) init(conf *Config, trans Transport) {
+	r.config = conf
+	r.transport = trans
+	r.vnodes = make([]*localVnode, conf.NumVnodes)
+	for i := range r.vnodes {
+		vn := &localVnode{ring: r}
+		r.vnodes[i] = vn
+		vn.init(i)
+	}
+}
+
+// setLocalSuccessors fills in any successor[0] slot that a remote
+// FindSuccessors call didn't already populate, by chaining local
+// vnodes to each other.
+func (r *Ring) setLocalSuccessors() {
+	numV := len(r.vnodes)
+	for i, vn := range r.vnodes {
+		if vn.successors[0] == nil {
+			vn.successors[0] = &r.vnodes[(i+1)%numV].Vnode
+		}
+	}
+}
+
+// start schedules the stabilize timer on every local vnode.
+func (r *Ring) start() {
+	for _, vn := range r.vnodes {
+		vn.schedule()
+	}
+}
+
+// Create creates a brand new ring with no existing members.
+func Create(conf *Config, trans Transport) (*Ring, error) {
+	r := &Ring{}
+	r.init(conf, trans)
+	r.setLocalSuccessors()
+	r.start()
+	return r, nil
+}
+
+// Join creates a Ring by locating an existing member to bootstrap
+// from. Before trying the caller-supplied bootstrap host, it consults
+// config.PeerStore (if any) and tries the most-recently-seen peers in
+// parallel, so a node that bounces reconnects to the ring without
+// depending on the bootstrap host still being up or even specified.
+func Join(conf *Config, trans Transport, bootstrapHost string) (*Ring, error) {
+	host := bootstrapHost
+	if seed := joinViaPeerStore(conf, trans); seed != "" {
+		host = seed
+	}
+	if host == "" {
+		return nil, fmt.Errorf("chord: no bootstrap host to join (no PeerStore seed, none supplied)")
+	}
+
+	hostVnodes, err := trans.ListVnodes(host)
+	if err != nil {
+		return nil, err
+	}
+	if len(hostVnodes) == 0 {
+		return nil, fmt.Errorf("chord: bootstrap host %q has no vnodes", host)
+	}
+
+	r := &Ring{}
+	r.init(conf, trans)
+
+	for _, vn := range r.vnodes {
+		succs, err := trans.FindSuccessors(hostVnodes[0], conf.NumSuccessors, vn.Id)
+		if err != nil {
+			return nil, err
+		}
+		copy(vn.successors, succs)
+	}
+
+	r.setLocalSuccessors()
+	r.start()
+	return r, nil
+}
+
+// joinViaPeerStore tries the most-recently-seen peer-store seeds in
+// parallel and returns the host of the first one that's still alive,
+// or "" if none answer (or there is no PeerStore configured). This is
+// what actually delivers the faster-rejoin-after-restart this Config
+// field exists for.
+func joinViaPeerStore(conf *Config, trans Transport) string {
+	seeds := seedsFromPeerStore(conf, joinSeedFanout)
+	if len(seeds) == 0 {
+		return ""
+	}
+
+	type pingResult struct {
+		host  string
+		alive bool
+	}
+	results := make(chan pingResult, len(seeds))
+
+	var wg sync.WaitGroup
+	for _, seed := range seeds {
+		wg.Add(1)
+		go func(s *PeerRecord) {
+			defer wg.Done()
+			alive, _ := trans.Ping(s.Vnode)
+			results <- pingResult{host: s.Host, alive: alive}
+		}(seed)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for res := range results {
+		if res.alive {
+			return res.host
+		}
+	}
+	return ""
+}
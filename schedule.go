@@ -0,0 +1,106 @@
+package chord
+
+import (
+	"sync"
+	"time"
+)
+
+// Maintenance task names, also used as RingStats() keys. Order here is
+// the priority order stabilize() runs them in: routing correctness
+// (successor, predecessor) is repaired before finger optimization.
+const (
+	taskSuccessor   = "successor"
+	taskPredecessor = "predecessor"
+	taskFinger      = "finger"
+)
+
+// maxTaskBackoff caps exponential back-off at 30x the base stabilize
+// interval, so a task never goes more than a few minutes without being
+// retried even after many consecutive failures.
+const maxTaskBackoff = 30
+
+// maxTaskBackoffShift bounds the exponent used to compute the back-off
+// multiplier. 1<<failures overflows an int and wraps to 0 once
+// failures gets large enough, so the shift itself must be capped
+// before maxTaskBackoff ever gets a chance to clamp the result.
+const maxTaskBackoffShift = 5
+
+// maxSuccessorFailures bounds how many consecutive checkNewSuccessor
+// failures we tolerate against the same successor before treating it
+// as dead and rotating it out, even if it still answers Ping.
+const maxSuccessorFailures = 3
+
+// taskState tracks the run history of a single maintenance task so
+// stabilize() can skip tasks that aren't due yet and back off ones
+// that keep failing.
+type taskState struct {
+	lastRun     time.Time
+	lastSuccess time.Time
+	failures    int
+	nextDue     time.Time
+}
+
+// taskSchedule holds the per-task state for a vnode's maintenance
+// loop, keyed by task name (taskSuccessor, taskPredecessor, taskFinger).
+type taskSchedule struct {
+	sync.Mutex
+	tasks map[string]*taskState
+}
+
+func newTaskSchedule() *taskSchedule {
+	s := &taskSchedule{tasks: make(map[string]*taskState)}
+	for _, name := range []string{taskSuccessor, taskPredecessor, taskFinger} {
+		s.tasks[name] = &taskState{}
+	}
+	return s
+}
+
+// due reports whether name's nextDue has passed.
+func (s *taskSchedule) due(name string) bool {
+	s.Lock()
+	defer s.Unlock()
+	return time.Now().After(s.tasks[name].nextDue)
+}
+
+// record updates name's state after a run. On failure the task backs
+// off exponentially, capped at maxTaskBackoff times base. On success
+// the failure count resets and the task is due again after base.
+func (s *taskSchedule) record(name string, base time.Duration, err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	t := s.tasks[name]
+	t.lastRun = time.Now()
+	if err == nil {
+		t.failures = 0
+		t.lastSuccess = t.lastRun
+		t.nextDue = t.lastRun.Add(base)
+		return
+	}
+
+	t.failures++
+	// Clamp before shifting: 1<<failures overflows int and wraps to 0
+	// once failures gets large, which would silently collapse the
+	// back-off right when it matters most (a long-dead peer).
+	shift := t.failures
+	if shift > maxTaskBackoffShift {
+		shift = maxTaskBackoffShift
+	}
+	mult := 1 << uint(shift)
+	if mult > maxTaskBackoff {
+		mult = maxTaskBackoff
+	}
+	t.nextDue = t.lastRun.Add(base * time.Duration(mult))
+}
+
+// snapshot returns a copy of the current state for RingStats.
+func (s *taskSchedule) snapshot() map[string]taskState {
+	s.Lock()
+	defer s.Unlock()
+
+	out := make(map[string]taskState, len(s.tasks))
+	for name, t := range s.tasks {
+		out[name] = *t
+	}
+	return out
+}
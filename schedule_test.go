@@ -0,0 +1,70 @@
+package chord
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTaskScheduleDueByDefault(t *testing.T) {
+	s := newTaskSchedule()
+	if !s.due(taskSuccessor) {
+		t.Fatalf("a freshly created task should be due immediately")
+	}
+}
+
+func TestTaskScheduleRecordSuccessResetsFailures(t *testing.T) {
+	s := newTaskSchedule()
+	base := 10 * time.Millisecond
+
+	s.record(taskSuccessor, base, errors.New("boom"))
+	s.record(taskSuccessor, base, nil)
+
+	snap := s.snapshot()[taskSuccessor]
+	if snap.failures != 0 {
+		t.Fatalf("expected failures reset to 0 after success, got %d", snap.failures)
+	}
+	if snap.lastSuccess.IsZero() {
+		t.Fatalf("expected lastSuccess to be set after a successful run")
+	}
+}
+
+func TestTaskScheduleBacksOffOnFailure(t *testing.T) {
+	s := newTaskSchedule()
+	base := 10 * time.Millisecond
+
+	s.record(taskSuccessor, base, errors.New("boom"))
+	if s.due(taskSuccessor) {
+		t.Fatalf("task should not be due immediately after a failure")
+	}
+}
+
+func TestTaskScheduleBackoffClampsAndNeverWrapsToZero(t *testing.T) {
+	s := newTaskSchedule()
+	base := time.Millisecond
+
+	// Enough consecutive failures to overflow 1<<failures if the
+	// shift weren't clamped first (1<<64 == 0 in Go).
+	for i := 0; i < 100; i++ {
+		s.record(taskSuccessor, base, errors.New("boom"))
+	}
+
+	snap := s.snapshot()[taskSuccessor]
+	wait := snap.nextDue.Sub(snap.lastRun)
+	if wait <= 0 {
+		t.Fatalf("back-off collapsed to zero after many failures, got wait=%s", wait)
+	}
+	if wait > base*maxTaskBackoff {
+		t.Fatalf("back-off exceeded the configured cap: wait=%s, cap=%s", wait, base*maxTaskBackoff)
+	}
+}
+
+func TestTaskScheduleTracksEveryTask(t *testing.T) {
+	want := []string{taskSuccessor, taskPredecessor, taskFinger}
+	s := newTaskSchedule()
+	for _, name := range want {
+		if _, ok := s.tasks[name]; !ok {
+			t.Fatalf("expected taskSchedule to track %q", name)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package chord
+
+import "time"
+
+// TaskStats reports the health of a single maintenance task
+// (taskSuccessor, taskPredecessor, taskFinger) on one vnode.
+type TaskStats struct {
+	LastRun     time.Time
+	LastSuccess time.Time
+	Failures    int
+}
+
+// VnodeStats reports the maintenance health of a single local vnode,
+// as tracked by its task schedule.
+type VnodeStats struct {
+	Vnode *Vnode
+	Tasks map[string]TaskStats
+}
+
+// RingStats reports per-vnode maintenance health across every local
+// vnode in the ring, so operators can see which vnodes are struggling
+// to reach their successor, predecessor, or finger entries without
+// digging through logs.
+func (r *Ring) RingStats() []VnodeStats {
+	stats := make([]VnodeStats, 0, len(r.vnodes))
+	for _, vn := range r.vnodes {
+		tasks := make(map[string]TaskStats)
+		for name, t := range vn.tasks.snapshot() {
+			tasks[name] = TaskStats{
+				LastRun:     t.lastRun,
+				LastSuccess: t.lastSuccess,
+				Failures:    t.failures,
+			}
+		}
+		stats = append(stats, VnodeStats{Vnode: &vn.Vnode, Tasks: tasks})
+	}
+	return stats
+}
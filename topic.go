@@ -0,0 +1,331 @@
+package chord
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Ticket grants an advertiser the right to register on a vnode for a
+// given topic. Tickets are short-lived and carry a wait duration that
+// grows with queue pressure, so hot topics naturally spread their
+// advertisers across the successor list instead of hammering one vnode.
+type Ticket struct {
+	Topic  string
+	Nonce  uint64
+	Issued time.Time
+	Wait   time.Duration
+}
+
+// Expired returns true once more than ticketGrace has passed since the
+// ticket's wait period elapsed, so it is no longer eligible for
+// RegisterAdvert.
+func (t *Ticket) Expired() bool {
+	return time.Since(t.Issued) > t.Wait+ticketGrace
+}
+
+// Advert is a single advertisement for a topic held by a vnode.
+type Advert struct {
+	Vnode  *Vnode
+	Expiry time.Time
+	Meta   []byte
+}
+
+// ticketGrace is the slack allowed past a ticket's wait time before it
+// is rejected by RegisterAdvert.
+const ticketGrace = 2 * time.Second
+
+// maxTopicQueue bounds the number of outstanding (issued but not yet
+// redeemed) tickets tracked per topic on a single vnode. RequestTicket
+// refuses new tickets once a topic is at capacity, which is what
+// actually makes the queue bounded rather than just the wait time
+// growing without limit.
+const maxTopicQueue = 64
+
+// maxPendingTicketAge is how long an issued-but-unredeemed ticket is
+// kept in the pending set before it's swept out as abandoned, so a
+// client that requests a ticket and never calls RegisterAdvert doesn't
+// permanently consume a queue slot.
+const maxPendingTicketAge = 35 * time.Second
+
+// topicQueue tracks outstanding tickets for a single topic on a vnode.
+// pending maps an issued nonce to the time it was issued, both to size
+// the next ticket's wait by current queue pressure and to let
+// RegisterAdvert validate that a presented ticket was actually issued
+// by this vnode and hasn't already been redeemed.
+type topicQueue struct {
+	nonce   uint64
+	pending map[uint64]time.Time
+}
+
+// prune removes pending entries older than maxPendingTicketAge. Called
+// with the topicTable lock already held.
+func (q *topicQueue) prune() {
+	if len(q.pending) == 0 {
+		return
+	}
+	cutoff := time.Now().Add(-maxPendingTicketAge)
+	for nonce, issued := range q.pending {
+		if issued.Before(cutoff) {
+			delete(q.pending, nonce)
+		}
+	}
+}
+
+// topicTable is the per-vnode state backing topic advertisement. It is
+// initialized in localVnode.init and drained during stabilize().
+type topicTable struct {
+	sync.Mutex
+	adverts map[string][]*Advert
+	queues  map[string]*topicQueue
+}
+
+func newTopicTable() *topicTable {
+	return &topicTable{
+		adverts: make(map[string][]*Advert),
+		queues:  make(map[string]*topicQueue),
+	}
+}
+
+// hashTopic hashes a topic name into the ring's key space using the
+// ring's configured hash function, the same way genId hashes host/idx.
+func hashTopic(vn *localVnode, topic string) []byte {
+	hash := vn.ring.config.HashFunc()
+	hash.Write([]byte(topic))
+	return hash.Sum(nil)
+}
+
+// RequestTicket issues a Ticket for topic, sizing the wait duration to
+// the current queue pressure (outstanding tickets) for that topic on
+// this vnode, and refusing to issue one at all once the queue is full.
+func (vn *localVnode) RequestTicket(topic string) (*Ticket, error) {
+	vn.topics.Lock()
+	defer vn.topics.Unlock()
+
+	q, ok := vn.topics.queues[topic]
+	if !ok {
+		q = &topicQueue{pending: make(map[uint64]time.Time)}
+		vn.topics.queues[topic] = q
+	}
+	q.prune()
+
+	if len(q.pending) >= maxTopicQueue {
+		return nil, fmt.Errorf("topic %q queue is full on this vnode", topic)
+	}
+
+	q.nonce++
+	nonce := q.nonce
+	now := time.Now()
+
+	wait := time.Duration(len(q.pending)) * 250 * time.Millisecond
+	if wait > 30*time.Second {
+		wait = 30 * time.Second
+	}
+
+	q.pending[nonce] = now
+	return &Ticket{
+		Topic:  topic,
+		Nonce:  nonce,
+		Issued: now,
+		Wait:   wait,
+	}, nil
+}
+
+// RegisterAdvert: RPC invoked by a remote vnode to register an
+// advertisement for topic, presenting a ticket previously obtained via
+// RequestTicket. The ticket's nonce must match one this vnode actually
+// issued and hasn't already redeemed, so a caller can't skip
+// RequestTicket and fabricate a zero-wait ticket to bypass admission
+// control.
+func (vn *localVnode) RegisterAdvert(topic string, ticket *Ticket, adv *Vnode, ttl time.Duration, meta []byte) error {
+	if ticket == nil || ticket.Topic != topic {
+		return fmt.Errorf("ticket does not match topic %q", topic)
+	}
+	if time.Since(ticket.Issued) < ticket.Wait {
+		return fmt.Errorf("ticket for %q not yet eligible, wait %s remaining", topic, ticket.Wait-time.Since(ticket.Issued))
+	}
+	if ticket.Expired() {
+		return fmt.Errorf("ticket for %q expired", topic)
+	}
+
+	vn.topics.Lock()
+	defer vn.topics.Unlock()
+
+	q, ok := vn.topics.queues[topic]
+	if !ok {
+		return fmt.Errorf("no ticket for %q was ever issued by this vnode", topic)
+	}
+	issued, ok := q.pending[ticket.Nonce]
+	if !ok {
+		return fmt.Errorf("ticket nonce %d for %q was not issued by this vnode, or was already redeemed", ticket.Nonce, topic)
+	}
+	if !issued.Equal(ticket.Issued) {
+		return fmt.Errorf("ticket nonce %d for %q does not match the issued timestamp on record", ticket.Nonce, topic)
+	}
+
+	if len(vn.topics.adverts[topic]) >= maxTopicQueue {
+		return fmt.Errorf("topic %q is at capacity on this vnode", topic)
+	}
+
+	// Redeem the ticket so it can't be replayed.
+	delete(q.pending, ticket.Nonce)
+
+	entry := &Advert{Vnode: adv, Expiry: time.Now().Add(ttl), Meta: meta}
+	for i, existing := range vn.topics.adverts[topic] {
+		if existing.Vnode.String() == adv.String() {
+			vn.topics.adverts[topic][i] = entry
+			return nil
+		}
+	}
+	vn.topics.adverts[topic] = append(vn.topics.adverts[topic], entry)
+	return nil
+}
+
+// QueryTopic: RPC invoked to return the live advertisers this vnode
+// knows about for topic, up to maxResults entries.
+func (vn *localVnode) QueryTopic(topic string, maxResults int) ([]*Advert, error) {
+	vn.topics.Lock()
+	defer vn.topics.Unlock()
+
+	now := time.Now()
+	var live []*Advert
+	for _, a := range vn.topics.adverts[topic] {
+		if a.Expiry.After(now) {
+			live = append(live, a)
+			if len(live) >= maxResults {
+				break
+			}
+		}
+	}
+	return live, nil
+}
+
+// expireTopics drops advertisements past their TTL and sweeps
+// abandoned pending tickets out of each topic's queue. Called once per
+// stabilize tick.
+func (vn *localVnode) expireTopics() {
+	vn.topics.Lock()
+	defer vn.topics.Unlock()
+
+	now := time.Now()
+	for topic, adverts := range vn.topics.adverts {
+		live := adverts[:0]
+		for _, a := range adverts {
+			if a.Expiry.After(now) {
+				live = append(live, a)
+			}
+		}
+		if len(live) == 0 {
+			delete(vn.topics.adverts, topic)
+			if q, ok := vn.topics.queues[topic]; ok && len(q.pending) == 0 {
+				delete(vn.topics.queues, topic)
+			}
+		} else {
+			vn.topics.adverts[topic] = live
+		}
+	}
+
+	for _, q := range vn.topics.queues {
+		q.prune()
+	}
+}
+
+// congestedWait is the ticket wait past which AdvertiseTopic treats a
+// successor as congested and moves on to the next one in the list
+// instead of blocking on it, so hot topics actually fan out across the
+// successor list instead of just making every caller wait in place.
+const congestedWait = 0
+
+// AdvertiseTopic registers this ring as an advertiser for topic. The
+// topic name is hashed into the key space, FindSuccessors locates the
+// responsible vnodes, and the advertisement is replicated to each one
+// using the ticket-based admission scheme to spread load away from hot
+// topics: a successor that hands back a non-zero wait is congested, so
+// it's skipped in favor of a less-loaded neighbour, and only tried
+// again (honoring its wait) if every successor turned out congested.
+func (r *Ring) AdvertiseTopic(topic string, ttl time.Duration, meta []byte) error {
+	vn := r.vnodes[0]
+	key := hashTopic(vn, topic)
+
+	succs, err := vn.FindSuccessors(vn.ring.config.NumSuccessors, key)
+	if err != nil {
+		return err
+	}
+
+	type pending struct {
+		vnode  *Vnode
+		ticket *Ticket
+	}
+	var congested []pending
+	var lastErr error
+	registered := 0
+
+	for _, s := range succs {
+		if s == nil {
+			continue
+		}
+		ticket, err := r.transport.RequestTicket(s, topic)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if ticket.Wait > congestedWait {
+			congested = append(congested, pending{vnode: s, ticket: ticket})
+			continue
+		}
+		if err := r.transport.RegisterAdvert(s, topic, ticket, &vn.Vnode, ttl, meta); err != nil {
+			lastErr = err
+			continue
+		}
+		registered++
+	}
+
+	// Every responsible vnode was congested: fall back to waiting out
+	// the least-congested one rather than failing the advertisement.
+	if registered == 0 && len(congested) > 0 {
+		best := congested[0]
+		for _, c := range congested[1:] {
+			if c.ticket.Wait < best.ticket.Wait {
+				best = c
+			}
+		}
+		time.Sleep(best.ticket.Wait)
+		if err := r.transport.RegisterAdvert(best.vnode, topic, best.ticket, &vn.Vnode, ttl, meta); err != nil {
+			lastErr = err
+		} else {
+			registered++
+		}
+	}
+
+	if registered == 0 && lastErr != nil {
+		return lastErr
+	}
+	return nil
+}
+
+// LookupTopic resolves the vnodes responsible for topic and gathers up
+// to maxResults live advertisers from among them.
+func (r *Ring) LookupTopic(topic string, maxResults int) ([]*Advert, error) {
+	vn := r.vnodes[0]
+	key := hashTopic(vn, topic)
+
+	succs, err := vn.FindSuccessors(vn.ring.config.NumSuccessors, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*Advert
+	for _, s := range succs {
+		if s == nil || len(results) >= maxResults {
+			continue
+		}
+		adverts, err := r.transport.QueryTopic(s, topic, maxResults-len(results))
+		if err != nil {
+			log.Printf("[ERR] Failed to query topic %q on %s: %s", topic, s.String(), err)
+			continue
+		}
+		results = append(results, adverts...)
+	}
+	return results, nil
+}
@@ -0,0 +1,77 @@
+package chord
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestTopicVnode() *localVnode {
+	return &localVnode{topics: newTopicTable()}
+}
+
+func TestTicketExpired(t *testing.T) {
+	tk := &Ticket{Issued: time.Now().Add(-(5 * time.Second)), Wait: time.Second}
+	if !tk.Expired() {
+		t.Fatalf("expected ticket issued well past wait+grace to be expired")
+	}
+
+	tk = &Ticket{Issued: time.Now(), Wait: time.Second}
+	if tk.Expired() {
+		t.Fatalf("expected a freshly issued ticket to not be expired")
+	}
+}
+
+func TestRegisterAdvertRejectsForgedTicket(t *testing.T) {
+	vn := newTestTopicVnode()
+	forged := &Ticket{Topic: "t", Nonce: 1, Issued: time.Now(), Wait: 0}
+
+	err := vn.RegisterAdvert("t", forged, &Vnode{Id: []byte{1}}, time.Minute, nil)
+	if err == nil {
+		t.Fatalf("expected a ticket never issued by RequestTicket to be rejected")
+	}
+}
+
+func TestRegisterAdvertRejectsReplayedTicket(t *testing.T) {
+	vn := newTestTopicVnode()
+	tk, err := vn.RequestTicket("t")
+	if err != nil {
+		t.Fatalf("RequestTicket: %v", err)
+	}
+	tk.Wait = 0 // avoid sleeping in the test
+
+	adv := &Vnode{Id: []byte{1}}
+	if err := vn.RegisterAdvert("t", tk, adv, time.Minute, nil); err != nil {
+		t.Fatalf("first RegisterAdvert should succeed: %v", err)
+	}
+	if err := vn.RegisterAdvert("t", tk, adv, time.Minute, nil); err == nil {
+		t.Fatalf("expected a redeemed ticket to be rejected on replay")
+	}
+}
+
+func TestRequestTicketWaitGrowsWithQueuePressure(t *testing.T) {
+	vn := newTestTopicVnode()
+
+	first, err := vn.RequestTicket("hot")
+	if err != nil {
+		t.Fatalf("RequestTicket: %v", err)
+	}
+	second, err := vn.RequestTicket("hot")
+	if err != nil {
+		t.Fatalf("RequestTicket: %v", err)
+	}
+	if second.Wait <= first.Wait {
+		t.Fatalf("expected wait to grow with outstanding tickets: first=%s second=%s", first.Wait, second.Wait)
+	}
+}
+
+func TestRequestTicketRefusesWhenQueueFull(t *testing.T) {
+	vn := newTestTopicVnode()
+	for i := 0; i < maxTopicQueue; i++ {
+		if _, err := vn.RequestTicket("full"); err != nil {
+			t.Fatalf("unexpected error filling the queue: %v", err)
+		}
+	}
+	if _, err := vn.RequestTicket("full"); err == nil {
+		t.Fatalf("expected RequestTicket to refuse once the topic queue is at capacity")
+	}
+}
@@ -0,0 +1,100 @@
+package chord
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"time"
+)
+
+// powerOffset computes (id + 2^exp) mod 2^mod, used by fixFingerTable
+// to find the key each finger entry is responsible for.
+func powerOffset(id []byte, exp int, mod int) []byte {
+	idInt := big.Int{}
+	idInt.SetBytes(id)
+
+	two := big.NewInt(2)
+	offset := big.Int{}
+	offset.Exp(two, big.NewInt(int64(exp)), nil)
+
+	sum := big.Int{}
+	sum.Add(&idInt, &offset)
+
+	ceil := big.Int{}
+	ceil.Exp(two, big.NewInt(int64(mod)), nil)
+
+	idInt.Mod(&sum, &ceil)
+	return idInt.Bytes()
+}
+
+// between checks if key lies strictly between id1 and id2 on the ring,
+// accounting for wraparound.
+func between(id1, id2, key []byte) bool {
+	if bytes.Compare(id1, id2) == -1 {
+		return bytes.Compare(id1, key) == -1 && bytes.Compare(key, id2) == -1
+	}
+	return bytes.Compare(id1, key) == -1 || bytes.Compare(key, id2) == -1
+}
+
+// betweenRightIncl is between, but with the right endpoint inclusive.
+func betweenRightIncl(id1, id2, key []byte) bool {
+	if bytes.Compare(id1, id2) == -1 {
+		return bytes.Compare(id1, key) == -1 && bytes.Compare(key, id2) <= 0
+	}
+	return bytes.Compare(id1, key) == -1 || bytes.Compare(key, id2) <= 0
+}
+
+// max returns the larger of a and b.
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// randStabilize picks a random stabilization interval within the
+// configured [StabilizeMin, StabilizeMax] range, so vnodes don't all
+// tick in lockstep.
+func randStabilize(conf *Config) time.Duration {
+	min := conf.StabilizeMin
+	max := conf.StabilizeMax
+	r := rand.Float64()
+	return time.Duration((r * float64(max-min)) + float64(min))
+}
+
+// ClosestPreceedingVnodeIterator walks a vnode's finger table and then
+// its successor list, from farthest to nearest, yielding the closest
+// known predecessor of key that it hasn't already returned.
+type ClosestPreceedingVnodeIterator struct {
+	key       []byte
+	vn        *localVnode
+	fingerIdx int
+	succIdx   int
+}
+
+func (cp *ClosestPreceedingVnodeIterator) init(vn *localVnode, key []byte) {
+	cp.key = key
+	cp.vn = vn
+	cp.fingerIdx = len(vn.finger) - 1
+	cp.succIdx = len(vn.successors) - 1
+}
+
+// Next returns the next candidate closest-preceding vnode, or nil once
+// both the finger table and successor list are exhausted.
+func (cp *ClosestPreceedingVnodeIterator) Next() (*Vnode, error) {
+	for cp.fingerIdx >= 0 {
+		node := cp.vn.finger[cp.fingerIdx]
+		cp.fingerIdx--
+		if node != nil && between(cp.vn.Id, cp.key, node.Id) {
+			return node, nil
+		}
+	}
+	for cp.succIdx >= 0 {
+		node := cp.vn.successors[cp.succIdx]
+		cp.succIdx--
+		if node != nil && between(cp.vn.Id, cp.key, node.Id) {
+			return node, nil
+		}
+	}
+	return nil, nil
+}
@@ -21,6 +21,9 @@ func (vn *localVnode) init(idx int) error {
 	// Initialize all state
 	vn.successors = make([]*Vnode, vn.ring.config.NumSuccessors)
 	vn.finger = make([]*Vnode, vn.ring.config.HashBits)
+	vn.topics = newTopicTable()
+	vn.tasks = newTaskSchedule()
+	vn.skew = newClockSkewState()
 
 	// Register with the RPC mechanism
 	vn.ring.transport.Register(&vn.Vnode, vn)
@@ -43,9 +46,16 @@ func (vn *localVnode) genId(idx uint16) {
 
 	// Use the hash as the ID
 	vn.Id = hash.Sum(nil)
+
+	// Bump our persisted incarnation so a restarted host's Vnode wins
+	// over any ghost entries still held by neighbours with the same Id.
+	vn.Incarnation = nextIncarnation(conf, idx)
 }
 
-// Called to periodically stabilize the vnode
+// Called to periodically stabilize the vnode. Tasks run in priority
+// order (successor-health, then predecessor-health, then finger-fix)
+// and only if their back-off schedule says they're due, so a flapping
+// peer or a slow finger fix no longer holds up routing-critical work.
 func (vn *localVnode) stabilize() {
 	// Clear the timer
 	vn.timer = nil
@@ -58,25 +68,40 @@ func (vn *localVnode) stabilize() {
 	// Setup the next stabilize timer
 	defer vn.schedule()
 
-	// Check for new successor
-	if err := vn.checkNewSuccessor(); err != nil {
-		log.Printf("[ERR] Error checking for new successor: %s", err)
+	base := randStabilize(vn.ring.config)
+
+	// Successor health: check for a new successor and notify it.
+	if vn.tasks.due(taskSuccessor) {
+		err := vn.checkNewSuccessor()
+		if err == nil {
+			err = vn.notifySuccessor()
+		}
+		if err != nil {
+			log.Printf("[ERR] Error maintaining successor: %s", err)
+		}
+		vn.tasks.record(taskSuccessor, base, err)
 	}
 
-	// Notify the successor
-	if err := vn.notifySuccessor(); err != nil {
-		log.Printf("[ERR] Error notifying successor: %s", err)
+	// Predecessor health
+	if vn.tasks.due(taskPredecessor) {
+		err := vn.checkPredecessor()
+		if err != nil {
+			log.Printf("[ERR] Error checking predecessor: %s", err)
+		}
+		vn.tasks.record(taskPredecessor, base, err)
 	}
 
 	// Finger table fix up
-	if err := vn.fixFingerTable(); err != nil {
-		log.Printf("[ERR] Error fixing finger table: %s", err)
+	if vn.tasks.due(taskFinger) {
+		err := vn.fixFingerTable()
+		if err != nil {
+			log.Printf("[ERR] Error fixing finger table: %s", err)
+		}
+		vn.tasks.record(taskFinger, base, err)
 	}
 
-	// Check the predecessor
-	if err := vn.checkPredecessor(); err != nil {
-		log.Printf("[ERR] Error checking predecessor: %s", err)
-	}
+	// Expire stale topic advertisements
+	vn.expireTopics()
 
 	// Set the last stabilized time
 	vn.stabilized = time.Now()
@@ -90,17 +115,28 @@ func (vn *localVnode) checkNewSuccessor() error {
 	maybe_suc, err := trans.GetPredecessor(succ)
 	if err != nil {
 		// Handle a dead successor
-		if alive, _ := trans.Ping(succ); !alive {
-			// Advance the successors list past the dead one...
+		alive, _ := trans.Ping(succ)
+		vn.succFailures++
+		if !alive || vn.succFailures > maxSuccessorFailures {
+			// Either confirmed dead, or flapping against this same
+			// successor for too many ticks in a row: rotate it out
+			// rather than keep re-pinging it every stabilize tick.
+			if vn.ring.config.PeerStore != nil {
+				vn.ring.config.PeerStore.MarkDead(succ)
+			}
 			copy(vn.successors[0:], vn.successors[1:])
 			vn.successors[len(vn.successors)-1] = nil
+			vn.succFailures = 0
 			return nil
 		}
 		return err
 	}
+	vn.succFailures = 0
 
-	// Check if we should replace our successor
-	if maybe_suc != nil && between(vn.Id, succ.Id, maybe_suc.Id) {
+	// Check if we should replace our successor: either a genuinely
+	// closer Vnode, or the same Id reporting back under a higher
+	// incarnation (the old successor host restarted).
+	if maybe_suc != nil && (between(vn.Id, succ.Id, maybe_suc.Id) || supersedes(maybe_suc, succ)) {
 		// Check if new successor is alive before switching
 		alive, err := trans.Ping(maybe_suc)
 		if alive && err == nil {
@@ -109,6 +145,8 @@ func (vn *localVnode) checkNewSuccessor() error {
 			return err
 		}
 	}
+
+	recordPeer(vn.ring.config.PeerStore, vn.successors[0], vn.successors[0].Host)
 	return nil
 }
 
@@ -126,28 +164,41 @@ func (vn *localVnode) notifySuccessor() error {
 		return err
 	}
 
+	// Piggyback an NTP-style timestamp sample, at most once per
+	// clockSkewSampleInterval, so skewed clocks get caught.
+	vn.maybeSampleClock(succ)
+
 	// Trim the successors list if too long
 	max_succ := vn.ring.config.NumSuccessors
 	if len(succ_list) > max_succ-1 {
 		succ_list = succ_list[:max_succ-1]
 	}
 
-	// Update local successors list
+	// Update local successors list. reconcileIncarnation doubles as the
+	// anti-entropy check: if we already hold a higher-incarnation
+	// Vnode for this Id than the one our successor just told us about,
+	// keep ours so the bump propagates in this round rather than
+	// waiting for a full finger repair cycle.
 	for idx, s := range succ_list {
 		if s == nil {
 			break
 		}
+		s = reconcileIncarnation(vn.successors[idx+1], s)
 		vn.successors[idx+1] = s
+		recordPeer(vn.ring.config.PeerStore, s, s.Host)
 	}
 	return nil
 }
 
 // RPC: Notify is invoked when a Vnode gets notified
 func (vn *localVnode) Notify(maybe_pred *Vnode) ([]*Vnode, error) {
-	// Check if we should update our predecessor
-	if vn.predecessor == nil || between(vn.predecessor.Id, vn.Id, maybe_pred.Id) {
+	// Check if we should update our predecessor: either a genuinely
+	// closer Vnode, or the same Id reporting back under a higher
+	// incarnation (our old predecessor host restarted).
+	if vn.predecessor == nil || between(vn.predecessor.Id, vn.Id, maybe_pred.Id) || supersedes(maybe_pred, vn.predecessor) {
 		vn.predecessor = maybe_pred
 	}
+	recordPeer(vn.ring.config.PeerStore, maybe_pred, maybe_pred.Host)
 
 	// Return our successors list
 	return vn.successors, nil
@@ -155,6 +206,16 @@ func (vn *localVnode) Notify(maybe_pred *Vnode) ([]*Vnode, error) {
 
 // Fixes up the finger table
 func (vn *localVnode) fixFingerTable() error {
+	// A detected clock skew past the configured threshold poisons
+	// finger repair until the operator acknowledges it, so a skewed
+	// node can't keep poisoning routes with bad finger entries.
+	vn.skew.Lock()
+	poisoned := vn.skew.poisoned
+	vn.skew.Unlock()
+	if poisoned {
+		return fmt.Errorf("finger fix refused: unacknowledged clock skew on %s", vn.String())
+	}
+
 	// Determine the offset
 	hb := vn.ring.config.HashBits
 	offset := powerOffset(vn.Id, vn.last_finger, hb)
@@ -168,6 +229,7 @@ func (vn *localVnode) fixFingerTable() error {
 
 	// Update the finger table
 	vn.finger[vn.last_finger] = node
+	recordPeer(vn.ring.config.PeerStore, node, node.Host)
 
 	// Try to skip as many finger entries as possible
 	for {
@@ -207,6 +269,9 @@ func (vn *localVnode) checkPredecessor() error {
 
 		// Predecessor is dead
 		if !res {
+			if vn.ring.config.PeerStore != nil {
+				vn.ring.config.PeerStore.MarkDead(vn.predecessor)
+			}
 			vn.predecessor = nil
 		}
 	}